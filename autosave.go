@@ -0,0 +1,60 @@
+package main
+
+import "fmt"
+
+// autosaver flushes conversation snapshots to disk off the REPL's hot path:
+// the REPL hands it a copy after each user message and again after each
+// reply (tool messages included) instead of saving only on exit, so a crash
+// mid-conversation loses at most the reply in progress and the snapshot
+// currently in flight.
+type autosaver struct {
+	filePath string
+	updates  chan Conversation
+	errs     chan error
+	done     chan struct{}
+}
+
+func newAutosaver(filePath string) *autosaver {
+	a := &autosaver{
+		filePath: filePath,
+		updates:  make(chan Conversation),
+		errs:     make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *autosaver) run() {
+	defer close(a.done)
+	for convo := range a.updates {
+		if err := saveConversation(a.filePath, &convo); err != nil {
+			select {
+			case a.errs <- fmt.Errorf("autosave: %w", err):
+			default:
+				// a previous error is still unread; drop this one rather
+				// than block the save loop on it.
+			}
+		}
+	}
+}
+
+// Trigger hands off a snapshot of convo to be flushed asynchronously. The
+// slice is copied so the caller is free to keep mutating convo.Messages.
+func (a *autosaver) Trigger(convo Conversation) {
+	messages := make([]Message, len(convo.Messages))
+	copy(messages, convo.Messages)
+	convo.Messages = messages
+	a.updates <- convo
+}
+
+// Errs reports save failures the caller can surface without interrupting
+// the REPL.
+func (a *autosaver) Errs() <-chan error { return a.errs }
+
+// Close stops accepting updates and waits for the in-flight save, if any,
+// to finish.
+func (a *autosaver) Close() {
+	close(a.updates)
+	<-a.done
+}
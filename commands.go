@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxAttachSize bounds how much of a file /attach will inline, so a stray
+// `/attach /dev/urandom` can't blow up the conversation (or the request to
+// the provider).
+const maxAttachSize = 64 * 1024
+
+// Command is a single slash command. Registering one (see RegisterCommand)
+// makes it available as "/name ..." inside the REPL.
+type Command interface {
+	// Name is what follows the slash, e.g. "system" for "/system".
+	Name() string
+	// Usage is a one-line help string, e.g. "/temp <0.0-2.0>". dispatchCommand
+	// does not print it; commands put their own usage text in their errors.
+	Usage() string
+	// Run executes the command against state, with arg holding everything
+	// after the command name (already trimmed), which may be empty. keys
+	// is the same raw key stream the REPL reads input from, for commands
+	// (like /retry) that need to stream a reply and watch for Ctrl-C.
+	Run(state *replState, arg string, keys <-chan byte) error
+}
+
+var commandRegistry = map[string]Command{}
+
+// RegisterCommand adds c to the set of slash commands the REPL recognizes.
+// Third parties can call this from an init() to add their own.
+func RegisterCommand(c Command) {
+	commandRegistry[c.Name()] = c
+}
+
+// funcCommand adapts a plain function to the Command interface, which is
+// all shllm's built-ins need.
+type funcCommand struct {
+	name  string
+	usage string
+	run   func(state *replState, arg string, keys <-chan byte) error
+}
+
+func (c *funcCommand) Name() string  { return c.name }
+func (c *funcCommand) Usage() string { return c.usage }
+func (c *funcCommand) Run(state *replState, arg string, keys <-chan byte) error {
+	return c.run(state, arg, keys)
+}
+
+func init() {
+	RegisterCommand(&funcCommand{"system", "/system <prompt>", cmdSlashSystem})
+	RegisterCommand(&funcCommand{"model", "/model <name>", cmdSlashModel})
+	RegisterCommand(&funcCommand{"temp", "/temp <0.0-2.0>", cmdSlashTemp})
+	RegisterCommand(&funcCommand{"max-tokens", "/max-tokens <n>", cmdSlashMaxTokens})
+	RegisterCommand(&funcCommand{"attach", "/attach <path>", cmdSlashAttach})
+	RegisterCommand(&funcCommand{"retry", "/retry", cmdSlashRetry})
+	RegisterCommand(&funcCommand{"undo", "/undo", cmdSlashUndo})
+	RegisterCommand(&funcCommand{"clear", "/clear", cmdSlashClear})
+	RegisterCommand(&funcCommand{"save", "/save", cmdSlashSave})
+	RegisterCommand(&funcCommand{"tokens", "/tokens", cmdSlashTokens})
+}
+
+// dispatchCommand parses a "/name arg..." line and runs the matching
+// Command, or reports an error for an unrecognized one rather than
+// silently forwarding it to the LLM.
+func dispatchCommand(state *replState, line string, keys <-chan byte) error {
+	line = strings.TrimPrefix(line, "/")
+	name, arg, _ := strings.Cut(line, " ")
+	cmd, ok := commandRegistry[name]
+	if !ok {
+		return fmt.Errorf("unknown command /%s", name)
+	}
+	return cmd.Run(state, strings.TrimSpace(arg), keys)
+}
+
+func cmdSlashSystem(state *replState, arg string, _ <-chan byte) error {
+	for i := range state.convo.Messages {
+		if state.convo.Messages[i].Role == "system" {
+			state.convo.Messages[i].Content = arg
+			return nil
+		}
+	}
+	state.convo.Messages = append([]Message{{Role: "system", Content: arg}}, state.convo.Messages...)
+	return nil
+}
+
+func cmdSlashModel(state *replState, arg string, _ <-chan byte) error {
+	if arg == "" {
+		return fmt.Errorf("usage: /model <name>")
+	}
+	state.params.Model = arg
+	return nil
+}
+
+func cmdSlashTemp(state *replState, arg string, _ <-chan byte) error {
+	temp, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return fmt.Errorf("usage: /temp <0.0-2.0>: %w", err)
+	}
+	state.params.Temperature = &temp
+	return nil
+}
+
+func cmdSlashMaxTokens(state *replState, arg string, _ <-chan byte) error {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("usage: /max-tokens <n>: %w", err)
+	}
+	state.params.MaxTokens = &n
+	return nil
+}
+
+func cmdSlashAttach(state *replState, arg string, _ <-chan byte) error {
+	if arg == "" {
+		return fmt.Errorf("usage: /attach <path>")
+	}
+	info, err := os.Stat(arg)
+	if err != nil {
+		return fmt.Errorf("attach %s: %w", arg, err)
+	}
+	if info.Size() > maxAttachSize {
+		return fmt.Errorf("attach %s: %d bytes exceeds the %d byte limit", arg, info.Size(), maxAttachSize)
+	}
+	// Read through a limit as well: device files like /dev/urandom report a
+	// size of 0 to Stat but never end.
+	f, err := os.Open(arg)
+	if err != nil {
+		return fmt.Errorf("attach %s: %w", arg, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxAttachSize+1))
+	if err != nil {
+		return fmt.Errorf("attach %s: %w", arg, err)
+	}
+	if len(data) > maxAttachSize {
+		return fmt.Errorf("attach %s: exceeds the %d byte limit", arg, maxAttachSize)
+	}
+
+	lang := strings.TrimPrefix(filepath.Ext(arg), ".")
+	content := fmt.Sprintf("%s:\n```%s\n%s\n```", arg, lang, data)
+	state.convo.Messages = append(state.convo.Messages, Message{Role: "user", Content: content, Timestamp: time.Now()})
+	return nil
+}
+
+func cmdSlashRetry(state *replState, _ string, keys <-chan byte) error {
+	if len(state.convo.Messages) == 0 || state.convo.Messages[len(state.convo.Messages)-1].Role != "assistant" {
+		return fmt.Errorf("/retry: no assistant reply to retry")
+	}
+	state.convo.Messages = state.convo.Messages[:len(state.convo.Messages)-1]
+
+	var updated Conversation
+	var err error
+	if state.toolConfig.enabled {
+		updated, err = completeWithTools(state.provider, state.convo, state.params, state.toolConfig, keys)
+	} else {
+		updated, err = streamUpdateConvo(state.provider, state.convo, state.params, keys)
+	}
+	if err != nil {
+		return err
+	}
+	state.convo = updated
+	state.autosave.Trigger(state.convo)
+	return nil
+}
+
+func cmdSlashUndo(state *replState, _ string, _ <-chan byte) error {
+	messages := state.convo.Messages
+	if len(messages) == 0 {
+		return fmt.Errorf("/undo: nothing to undo")
+	}
+	if len(messages) > 0 && messages[len(messages)-1].Role == "assistant" {
+		messages = messages[:len(messages)-1]
+	}
+	if len(messages) > 0 && messages[len(messages)-1].Role == "user" {
+		messages = messages[:len(messages)-1]
+	}
+	state.convo.Messages = messages
+	return nil
+}
+
+func cmdSlashClear(state *replState, _ string, _ <-chan byte) error {
+	id, err := newConversationID()
+	if err != nil {
+		return err
+	}
+	state.convo.ID = id
+	state.convo.Messages = nil
+	state.convo.CreatedAt = time.Now()
+	return nil
+}
+
+func cmdSlashSave(state *replState, _ string, _ <-chan byte) error {
+	state.autosave.Trigger(state.convo)
+	return nil
+}
+
+func cmdSlashTokens(state *replState, _ string, _ <-chan byte) error {
+	chars := 0
+	for _, m := range state.convo.Messages {
+		chars += len(m.Content)
+	}
+	// Rough estimate: ~4 characters per token, the same heuristic OpenAI's
+	// own docs suggest in the absence of a real tokenizer.
+	fmt.Printf("~%d tokens (%d messages)\r\n", chars/4, len(state.convo.Messages))
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeProvider is a minimal Provider for exercising commands that need one,
+// without talking to a real backend.
+type fakeProvider struct {
+	reply Message
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Complete(_ context.Context, _ Conversation, _ GenParams) (Message, error) {
+	return p.reply, nil
+}
+
+func (p *fakeProvider) Stream(_ context.Context, _ Conversation, _ GenParams) (<-chan Delta, error) {
+	deltas := make(chan Delta, 2)
+	deltas <- Delta{Content: p.reply.Content}
+	deltas <- Delta{Done: true}
+	close(deltas)
+	return deltas, nil
+}
+
+func TestDispatchCommandUnknown(t *testing.T) {
+	state := &replState{}
+	if err := dispatchCommand(state, "/nope", nil); err == nil {
+		t.Error("got nil error for an unknown command, want one")
+	}
+}
+
+func TestDispatchCommandRunsMatching(t *testing.T) {
+	state := &replState{}
+	if err := dispatchCommand(state, "/model gpt-5", nil); err != nil {
+		t.Fatalf("dispatchCommand: %v", err)
+	}
+	if state.params.Model != "gpt-5" {
+		t.Errorf("got model %q, want %q", state.params.Model, "gpt-5")
+	}
+}
+
+func TestCmdSlashUndoDropsLastExchange(t *testing.T) {
+	state := &replState{convo: Conversation{Messages: []Message{
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "hello"},
+	}}}
+	if err := cmdSlashUndo(state, "", nil); err != nil {
+		t.Fatalf("cmdSlashUndo: %v", err)
+	}
+	if len(state.convo.Messages) != 0 {
+		t.Errorf("got %d messages left, want 0", len(state.convo.Messages))
+	}
+}
+
+func TestCmdSlashUndoDropsOnlyUser(t *testing.T) {
+	state := &replState{convo: Conversation{Messages: []Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "hi"},
+	}}}
+	if err := cmdSlashUndo(state, "", nil); err != nil {
+		t.Fatalf("cmdSlashUndo: %v", err)
+	}
+	if len(state.convo.Messages) != 1 || state.convo.Messages[0].Role != "system" {
+		t.Errorf("got %+v, want only the system message left", state.convo.Messages)
+	}
+}
+
+func TestCmdSlashUndoEmptyConvo(t *testing.T) {
+	state := &replState{}
+	if err := cmdSlashUndo(state, "", nil); err == nil {
+		t.Error("got nil error for an empty conversation, want one")
+	}
+}
+
+func TestCmdSlashRetryReplacesLastReply(t *testing.T) {
+	provider := &fakeProvider{reply: Message{Role: "assistant", Content: "second try"}}
+	state := &replState{
+		provider: provider,
+		autosave: newAutosaver(t.TempDir() + "/archive.json"),
+		convo: Conversation{Messages: []Message{
+			{Role: "user", Content: "hi"},
+			{Role: "assistant", Content: "first try"},
+		}},
+	}
+	defer state.autosave.Close()
+
+	if err := cmdSlashRetry(state, "", nil); err != nil {
+		t.Fatalf("cmdSlashRetry: %v", err)
+	}
+	if len(state.convo.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(state.convo.Messages))
+	}
+	if got := state.convo.Messages[1].Content; got != "second try" {
+		t.Errorf("got reply %q, want %q", got, "second try")
+	}
+}
+
+func TestCmdSlashRetryNoAssistantReply(t *testing.T) {
+	state := &replState{convo: Conversation{Messages: []Message{{Role: "user", Content: "hi"}}}}
+	if err := cmdSlashRetry(state, "", nil); err == nil {
+		t.Error("got nil error with no assistant reply to retry, want one")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+const defaultConfigPath = "~/.config/shllm/config.toml"
+
+// Config holds the defaults read from config.toml. Command-line flags take
+// precedence over whatever is set here.
+type Config struct {
+	Provider string `toml:"provider"`
+	Model    string `toml:"model"`
+	BaseURL  string `toml:"base_url"`
+	// WebFetchAllowlist restricts the web_fetch tool to these hosts. Empty
+	// means web_fetch refuses every request.
+	WebFetchAllowlist []string `toml:"web_fetch_allowlist"`
+}
+
+// loadConfig reads the config file at path, returning a zero Config if the
+// file doesn't exist.
+func loadConfig(path string) (Config, error) {
+	path = expandUser(path)
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, fmt.Errorf("read config %s: %w", path, err)
+	}
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// defaultConfigFilePath returns where loadConfig looks when the user hasn't
+// overridden it with a flag: config.toml under $XDG_CONFIG_HOME when set,
+// else defaultConfigPath with "~" left for loadConfig to expand.
+func defaultConfigFilePath() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "shllm", "config.toml")
+	}
+	return defaultConfigPath
+}
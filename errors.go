@@ -0,0 +1,19 @@
+package main
+
+import "errors"
+
+// Sentinel errors callers can match against with errors.Is, wrapped with
+// %w throughout the codebase so the original cause is never lost.
+var (
+	// ErrProviderUnavailable means the configured Provider couldn't be
+	// constructed or reached.
+	ErrProviderUnavailable = errors.New("provider unavailable")
+	// ErrSessionCorrupt means an on-disk session file couldn't be parsed.
+	ErrSessionCorrupt = errors.New("session file corrupt")
+	// ErrRateLimited means the provider rejected a request for exceeding
+	// its rate limit.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrToolsUnsupported means GenParams.Tools was set for a provider that
+	// has no function-calling schema to put them in.
+	ErrToolsUnsupported = errors.New("tool calling not supported by this provider")
+)
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Delta represents one incremental chunk of a streamed assistant reply.
+type Delta struct {
+	Content      string
+	FinishReason string
+	Done         bool
+}
+
+// GenParams carries per-request sampling overrides, e.g. from the REPL's
+// /model, /temp and /max-tokens commands. A zero GenParams means "use the
+// provider's own defaults".
+type GenParams struct {
+	Model       string
+	Temperature *float64
+	MaxTokens   *int
+	// Tools, when non-empty, is offered to the model as the set of local
+	// functions it may call. Only Complete needs to honor it: shllm only
+	// runs the tool-calling loop against non-streaming replies.
+	Tools []ToolDef
+}
+
+// Provider abstracts over an LLM backend capable of producing chat
+// completions, so shllm isn't hard-wired to a single API shape.
+type Provider interface {
+	// Name returns the provider's identifier, as passed to -provider.
+	Name() string
+	// Complete sends convo and returns the full assistant reply.
+	Complete(ctx context.Context, convo Conversation, params GenParams) (Message, error)
+	// Stream sends convo and streams the assistant reply incrementally.
+	// The returned channel is closed after the final Delta (Done == true),
+	// or without one if ctx is cancelled or the response ends early.
+	Stream(ctx context.Context, convo Conversation, params GenParams) (<-chan Delta, error)
+}
+
+// ProviderConfig holds the settings needed to construct any Provider.
+type ProviderConfig struct {
+	Model   string
+	BaseURL string
+	APIKey  string
+}
+
+// NewProvider builds the Provider registered under name, applying
+// per-provider defaults (base URL, API key env var) where cfg leaves them
+// blank.
+func NewProvider(name string, cfg ProviderConfig) (Provider, error) {
+	switch name {
+	case "openai":
+		return newOpenAIProvider(cfg), nil
+	case "anthropic":
+		return newAnthropicProvider(cfg), nil
+	case "ollama":
+		return newOllamaProvider(cfg), nil
+	case "llamacpp":
+		return newLlamaCppProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrProviderUnavailable, name)
+	}
+}
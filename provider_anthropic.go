@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion        = "2023-06-01"
+	anthropicMaxTokens      = 4096
+)
+
+// anthropicProvider talks to Anthropic's /v1/messages endpoint.
+type anthropicProvider struct {
+	model   string
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func newAnthropicProvider(cfg ProviderConfig) *anthropicProvider {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultAnthropicBaseURL
+	}
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	return &anthropicProvider{
+		model:   cfg.Model,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}
+}
+
+func (p *anthropicProvider) Name() string { return "anthropic" }
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// anthropicContentBlock covers both directions of Anthropic's content-block
+// shape: plain text, a tool call the assistant is making ("tool_use"), and a
+// tool's result being fed back ("tool_result", sent on a "user" message since
+// Anthropic has no "tool" role).
+type anthropicContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Stream      bool               `json:"stream"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	Tools       []anthropicToolDef `json:"tools,omitempty"`
+}
+
+// anthropicToolDef is Anthropic's shape for a ToolDef: input_schema instead
+// of OpenAI's nested "function" object.
+type anthropicToolDef struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema,omitempty"`
+}
+
+func anthropicToolDefs(tools []ToolDef) []anthropicToolDef {
+	if len(tools) == 0 {
+		return nil
+	}
+	defs := make([]anthropicToolDef, len(tools))
+	for i, t := range tools {
+		defs[i] = anthropicToolDef{Name: t.Name, Description: t.Description, InputSchema: t.Parameters}
+	}
+	return defs
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		ID    string          `json:"id"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+}
+
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+}
+
+// splitSystem pulls out the (first) "system" message, since Anthropic takes
+// it as a top-level field rather than a message in the list, and translates
+// the rest into Anthropic's content-block shape. Anthropic has no "tool"
+// role: a tool result becomes a "tool_result" block on a "user" message, and
+// consecutive tool messages (answering a single assistant turn's multiple
+// ToolCalls) are folded into one such message, since Anthropic expects all
+// the results for a turn together.
+func splitSystem(messages []Message) (system string, rest []anthropicMessage) {
+	for _, m := range messages {
+		if m.Role == "system" && system == "" {
+			system = m.Content
+			continue
+		}
+		if m.Role == "tool" {
+			block := anthropicContentBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content}
+			if n := len(rest); n > 0 && rest[n-1].Role == "user" && isToolResult(rest[n-1]) {
+				rest[n-1].Content = append(rest[n-1].Content, block)
+				continue
+			}
+			rest = append(rest, anthropicMessage{Role: "user", Content: []anthropicContentBlock{block}})
+			continue
+		}
+
+		var blocks []anthropicContentBlock
+		if m.Content != "" {
+			blocks = append(blocks, anthropicContentBlock{Type: "text", Text: m.Content})
+		}
+		for _, call := range m.ToolCalls {
+			blocks = append(blocks, anthropicContentBlock{
+				Type:  "tool_use",
+				ID:    call.ID,
+				Name:  call.Function.Name,
+				Input: json.RawMessage(call.Function.Arguments),
+			})
+		}
+		if len(blocks) == 0 {
+			// Anthropic rejects a message with an empty content array.
+			blocks = append(blocks, anthropicContentBlock{Type: "text"})
+		}
+		rest = append(rest, anthropicMessage{Role: m.Role, Content: blocks})
+	}
+	return system, rest
+}
+
+// isToolResult reports whether msg is a "user" message made entirely of
+// tool_result blocks, i.e. one splitSystem folded earlier "tool" messages
+// into, so a subsequent tool result can still be appended to it.
+func isToolResult(msg anthropicMessage) bool {
+	if len(msg.Content) == 0 {
+		return false
+	}
+	for _, block := range msg.Content {
+		if block.Type != "tool_result" {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *anthropicProvider) newRequest(ctx context.Context, convo Conversation, params GenParams, stream bool) (*http.Request, error) {
+	model := p.model
+	if params.Model != "" {
+		model = params.Model
+	}
+	maxTokens := anthropicMaxTokens
+	if params.MaxTokens != nil {
+		maxTokens = *params.MaxTokens
+	}
+	system, messages := splitSystem(convo.Messages)
+	jsonBody, err := json.Marshal(anthropicRequest{
+		Model:       model,
+		System:      system,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Stream:      stream,
+		Temperature: params.Temperature,
+		Tools:       anthropicToolDefs(params.Tools),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/messages", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("anthropic-version", anthropicVersion)
+	if p.apiKey != "" {
+		req.Header.Set("x-api-key", p.apiKey)
+	}
+	return req, nil
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, convo Conversation, params GenParams) (Message, error) {
+	req, err := p.newRequest(ctx, convo, params, false)
+	if err != nil {
+		return Message{}, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Message{}, fmt.Errorf("anthropic request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Message{}, fmt.Errorf("read anthropic response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return Message{}, fmt.Errorf("%w: anthropic: %s", ErrRateLimited, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Message{}, fmt.Errorf("anthropic returned %s: %s", resp.Status, body)
+	}
+
+	var parsed anthropicResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Message{}, fmt.Errorf("unmarshal anthropic response: %w", err)
+	}
+	var text strings.Builder
+	var toolCalls []ToolCall
+	for _, block := range parsed.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			toolCalls = append(toolCalls, ToolCall{
+				ID:   block.ID,
+				Type: "function",
+				Function: ToolCallFunction{
+					Name:      block.Name,
+					Arguments: string(block.Input),
+				},
+			})
+		}
+	}
+	return Message{
+		Role:         "assistant",
+		Content:      text.String(),
+		Timestamp:    time.Now(),
+		FinishReason: parsed.StopReason,
+		ToolCalls:    toolCalls,
+	}, nil
+}
+
+func (p *anthropicProvider) Stream(ctx context.Context, convo Conversation, params GenParams) (<-chan Delta, error) {
+	req, err := p.newRequest(ctx, convo, params, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("anthropic returned %s: %s", resp.Status, body)
+	}
+
+	deltas := make(chan Delta)
+	go func() {
+		defer resp.Body.Close()
+		defer close(deltas)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			var event anthropicStreamEvent
+			if err := json.Unmarshal([]byte(payload), &event); err != nil {
+				continue
+			}
+			switch event.Type {
+			case "content_block_delta":
+				select {
+				case deltas <- Delta{Content: event.Delta.Text}:
+				case <-ctx.Done():
+					return
+				}
+			case "message_delta":
+				if event.Delta.StopReason != "" {
+					deltas <- Delta{FinishReason: event.Delta.StopReason, Done: true}
+					return
+				}
+			case "message_stop":
+				deltas <- Delta{Done: true}
+				return
+			}
+		}
+		deltas <- Delta{Done: true}
+	}()
+	return deltas, nil
+}
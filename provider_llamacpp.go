@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultLlamaCppBaseURL = "http://localhost:8080"
+
+// llamaCppProvider talks to llama.cpp's native server, whose /completion
+// endpoint takes a flat prompt string rather than a messages array.
+type llamaCppProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newLlamaCppProvider(cfg ProviderConfig) *llamaCppProvider {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultLlamaCppBaseURL
+	}
+	return &llamaCppProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+func (p *llamaCppProvider) Name() string { return "llamacpp" }
+
+// renderPrompt flattens a conversation into the plain-text transcript
+// llama.cpp's /completion endpoint expects.
+func renderPrompt(messages []Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "### %s:\n%s\n\n", m.Role, m.Content)
+	}
+	b.WriteString("### assistant:\n")
+	return b.String()
+}
+
+type llamaCppRequest struct {
+	Prompt      string   `json:"prompt"`
+	Stream      bool     `json:"stream"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	NPredict    *int     `json:"n_predict,omitempty"`
+}
+
+type llamaCppResponse struct {
+	Content      string `json:"content"`
+	Stop         bool   `json:"stop"`
+	StoppingWord string `json:"stopping_word"`
+}
+
+func (p *llamaCppProvider) newRequest(ctx context.Context, convo Conversation, params GenParams, stream bool) (*http.Request, error) {
+	jsonBody, err := json.Marshal(llamaCppRequest{
+		Prompt:      renderPrompt(convo.Messages),
+		Stream:      stream,
+		Temperature: params.Temperature,
+		NPredict:    params.MaxTokens,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/completion", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func (p *llamaCppProvider) Complete(ctx context.Context, convo Conversation, params GenParams) (Message, error) {
+	if len(params.Tools) > 0 {
+		return Message{}, fmt.Errorf("%w: llamacpp's /completion endpoint has no function-calling schema", ErrToolsUnsupported)
+	}
+	req, err := p.newRequest(ctx, convo, params, false)
+	if err != nil {
+		return Message{}, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Message{}, fmt.Errorf("llamacpp request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Message{}, fmt.Errorf("read llamacpp response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Message{}, fmt.Errorf("llamacpp returned %s: %s", resp.Status, body)
+	}
+
+	var parsed llamaCppResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Message{}, fmt.Errorf("unmarshal llamacpp response: %w", err)
+	}
+	return Message{Role: "assistant", Content: parsed.Content, Timestamp: time.Now()}, nil
+}
+
+func (p *llamaCppProvider) Stream(ctx context.Context, convo Conversation, params GenParams) (<-chan Delta, error) {
+	if len(params.Tools) > 0 {
+		return nil, fmt.Errorf("%w: llamacpp's /completion endpoint has no function-calling schema", ErrToolsUnsupported)
+	}
+	req, err := p.newRequest(ctx, convo, params, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("llamacpp request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("llamacpp returned %s: %s", resp.Status, body)
+	}
+
+	deltas := make(chan Delta)
+	go func() {
+		defer resp.Body.Close()
+		defer close(deltas)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			var chunk llamaCppResponse
+			if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+				continue
+			}
+			select {
+			case deltas <- Delta{
+				Content:      chunk.Content,
+				FinishReason: chunk.StoppingWord,
+				Done:         chunk.Stop,
+			}:
+			case <-ctx.Done():
+				return
+			}
+			if chunk.Stop {
+				return
+			}
+		}
+	}()
+	return deltas, nil
+}
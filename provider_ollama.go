@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// ollamaProvider talks to a local Ollama server's /api/chat endpoint.
+type ollamaProvider struct {
+	model   string
+	baseURL string
+	client  *http.Client
+}
+
+func newOllamaProvider(cfg ProviderConfig) *ollamaProvider {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	return &ollamaProvider{
+		model:   cfg.Model,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+func (p *ollamaProvider) Name() string { return "ollama" }
+
+type ollamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumPredict  *int     `json:"num_predict,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+	Tools    []ollamaToolDef `json:"tools,omitempty"`
+}
+
+// ollamaMessage is the wire shape of an /api/chat message: unlike Message,
+// it carries nothing from shllm's own archive format (Timestamp,
+// FinishReason) that Ollama's API doesn't ask for.
+type ollamaMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+}
+
+func toOllamaMessages(messages []Message) []ollamaMessage {
+	out := make([]ollamaMessage, len(messages))
+	for i, m := range messages {
+		out[i] = ollamaMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCalls:  m.ToolCalls,
+			ToolCallID: m.ToolCallID,
+			Name:       m.Name,
+		}
+	}
+	return out
+}
+
+// ollamaToolDef mirrors OpenAI's "function" tool shape, which Ollama's
+// /api/chat reuses.
+type ollamaToolDef struct {
+	Type     string             `json:"type"`
+	Function ollamaToolDefInner `json:"function"`
+}
+
+type ollamaToolDefInner struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+func ollamaToolDefs(tools []ToolDef) []ollamaToolDef {
+	if len(tools) == 0 {
+		return nil
+	}
+	defs := make([]ollamaToolDef, len(tools))
+	for i, t := range tools {
+		defs[i] = ollamaToolDef{
+			Type:     "function",
+			Function: ollamaToolDefInner{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		}
+	}
+	return defs
+}
+
+// ollamaToolCall is the shape Ollama puts tool calls in: unlike OpenAI's
+// API, there's no call ID and arguments arrive as a JSON object rather
+// than an encoded string, so it can't unmarshal directly into ToolCall.
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaResponseMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+// toMessage converts an Ollama response message into shllm's Message shape,
+// synthesizing a call ID (Ollama doesn't issue one) so tool results can
+// still be linked back by ToolCallID.
+func (m ollamaResponseMessage) toMessage() Message {
+	msg := Message{Role: m.Role, Content: m.Content}
+	for i, call := range m.ToolCalls {
+		msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+			ID:   fmt.Sprintf("call_%d", i),
+			Type: "function",
+			Function: ToolCallFunction{
+				Name:      call.Function.Name,
+				Arguments: string(call.Function.Arguments),
+			},
+		})
+	}
+	return msg
+}
+
+type ollamaChatLine struct {
+	Message    ollamaResponseMessage `json:"message"`
+	Done       bool                  `json:"done"`
+	DoneReason string                `json:"done_reason"`
+}
+
+func (p *ollamaProvider) newRequest(ctx context.Context, convo Conversation, params GenParams, stream bool) (*http.Request, error) {
+	model := p.model
+	if params.Model != "" {
+		model = params.Model
+	}
+	var options *ollamaOptions
+	if params.Temperature != nil || params.MaxTokens != nil {
+		options = &ollamaOptions{Temperature: params.Temperature, NumPredict: params.MaxTokens}
+	}
+	jsonBody, err := json.Marshal(ollamaChatRequest{
+		Model:    model,
+		Messages: toOllamaMessages(convo.Messages),
+		Stream:   stream,
+		Options:  options,
+		Tools:    ollamaToolDefs(params.Tools),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/chat", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func (p *ollamaProvider) Complete(ctx context.Context, convo Conversation, params GenParams) (Message, error) {
+	req, err := p.newRequest(ctx, convo, params, false)
+	if err != nil {
+		return Message{}, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Message{}, fmt.Errorf("ollama request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Message{}, fmt.Errorf("read ollama response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Message{}, fmt.Errorf("ollama returned %s: %s", resp.Status, body)
+	}
+
+	var parsed ollamaChatLine
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Message{}, fmt.Errorf("unmarshal ollama response: %w", err)
+	}
+	msg := parsed.Message.toMessage()
+	msg.Timestamp = time.Now()
+	return msg, nil
+}
+
+func (p *ollamaProvider) Stream(ctx context.Context, convo Conversation, params GenParams) (<-chan Delta, error) {
+	req, err := p.newRequest(ctx, convo, params, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama returned %s: %s", resp.Status, body)
+	}
+
+	deltas := make(chan Delta)
+	go func() {
+		defer resp.Body.Close()
+		defer close(deltas)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			var chunk ollamaChatLine
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				continue
+			}
+			select {
+			case deltas <- Delta{
+				Content:      chunk.Message.Content,
+				FinishReason: chunk.DoneReason,
+				Done:         chunk.Done,
+			}:
+			case <-ctx.Done():
+				return
+			}
+			if chunk.Done {
+				return
+			}
+		}
+	}()
+	return deltas, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestOllamaResponseMessageToMessagePlainText(t *testing.T) {
+	msg := ollamaResponseMessage{Role: "assistant", Content: "hi there"}.toMessage()
+
+	if msg.Role != "assistant" || msg.Content != "hi there" {
+		t.Errorf("got %+v, want role=assistant content=%q", msg, "hi there")
+	}
+	if len(msg.ToolCalls) != 0 {
+		t.Errorf("got %d tool calls, want 0", len(msg.ToolCalls))
+	}
+}
+
+func TestOllamaResponseMessageToMessageSynthesizesCallIDs(t *testing.T) {
+	var m ollamaResponseMessage
+	raw := `{
+		"role": "assistant",
+		"tool_calls": [
+			{"function": {"name": "read_file", "arguments": {"path": "a.txt"}}},
+			{"function": {"name": "list_dir", "arguments": {"path": "."}}}
+		]
+	}`
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	msg := m.toMessage()
+	if len(msg.ToolCalls) != 2 {
+		t.Fatalf("got %d tool calls, want 2", len(msg.ToolCalls))
+	}
+	if msg.ToolCalls[0].ID == "" || msg.ToolCalls[0].ID == msg.ToolCalls[1].ID {
+		t.Errorf("got call IDs %q and %q, want distinct non-empty IDs", msg.ToolCalls[0].ID, msg.ToolCalls[1].ID)
+	}
+	if msg.ToolCalls[0].Function.Name != "read_file" || msg.ToolCalls[0].Function.Arguments != `{"path": "a.txt"}` {
+		t.Errorf("got %+v, want name=read_file arguments preserved verbatim as the raw JSON object", msg.ToolCalls[0].Function)
+	}
+	if msg.ToolCalls[0].Type != "function" {
+		t.Errorf("got type %q, want %q", msg.ToolCalls[0].Type, "function")
+	}
+}
+
+func TestToOllamaMessagesOmitsArchiveFields(t *testing.T) {
+	messages := toOllamaMessages([]Message{
+		{Role: "user", Content: "hi", Timestamp: time.Now(), FinishReason: "stop"},
+	})
+
+	data, err := json.Marshal(messages[0])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for _, field := range []string{"timestamp", "finish_reason", "tool_call_id", "name", "tool_calls"} {
+		if _, ok := raw[field]; ok {
+			t.Errorf("wire message unexpectedly carries %q: %s", field, data)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com"
+
+// openAIProvider talks to any OpenAI-compatible chat-completions endpoint.
+type openAIProvider struct {
+	model   string
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+func newOpenAIProvider(cfg ProviderConfig) *openAIProvider {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return &openAIProvider{
+		model:   cfg.Model,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}
+}
+
+func (p *openAIProvider) Name() string { return "openai" }
+
+type openAIChatRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Stream      bool            `json:"stream"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	MaxTokens   *int            `json:"max_tokens,omitempty"`
+	Tools       []openAIToolDef `json:"tools,omitempty"`
+}
+
+// openAIMessage is the wire shape of a chat-completions message: unlike
+// Message, it carries nothing from shllm's own archive format (Timestamp,
+// FinishReason) that OpenAI's API doesn't ask for.
+type openAIMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+}
+
+func toOpenAIMessages(messages []Message) []openAIMessage {
+	out := make([]openAIMessage, len(messages))
+	for i, m := range messages {
+		out[i] = openAIMessage{
+			Role:       m.Role,
+			Content:    m.Content,
+			ToolCalls:  m.ToolCalls,
+			ToolCallID: m.ToolCallID,
+			Name:       m.Name,
+		}
+	}
+	return out
+}
+
+// openAIToolDef is OpenAI's wrapper around a ToolDef: a "function" typed
+// tool carrying the name, description and JSON-schema parameters.
+type openAIToolDef struct {
+	Type     string             `json:"type"`
+	Function openAIToolDefInner `json:"function"`
+}
+
+type openAIToolDefInner struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+func openAIToolDefs(tools []ToolDef) []openAIToolDef {
+	if len(tools) == 0 {
+		return nil
+	}
+	defs := make([]openAIToolDef, len(tools))
+	for i, t := range tools {
+		defs[i] = openAIToolDef{
+			Type: "function",
+			Function: openAIToolDefInner{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		}
+	}
+	return defs
+}
+
+// openAIResponseMessage is the wire shape of a chat-completions reply
+// message, narrower than Message for the same reason openAIMessage is.
+type openAIResponseMessage struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+func (m openAIResponseMessage) toMessage() Message {
+	return Message{Role: m.Role, Content: m.Content, ToolCalls: m.ToolCalls}
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message      openAIResponseMessage `json:"message"`
+		FinishReason string                `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+type openAIChatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+func (p *openAIProvider) newRequest(ctx context.Context, convo Conversation, params GenParams, stream bool) (*http.Request, error) {
+	model := p.model
+	if params.Model != "" {
+		model = params.Model
+	}
+	jsonBody, err := json.Marshal(openAIChatRequest{
+		Model:       model,
+		Messages:    toOpenAIMessages(convo.Messages),
+		Stream:      stream,
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
+		Tools:       openAIToolDefs(params.Tools),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	return req, nil
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, convo Conversation, params GenParams) (Message, error) {
+	req, err := p.newRequest(ctx, convo, params, false)
+	if err != nil {
+		return Message{}, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Message{}, fmt.Errorf("openai request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Message{}, fmt.Errorf("read openai response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return Message{}, fmt.Errorf("%w: openai: %s", ErrRateLimited, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Message{}, fmt.Errorf("openai returned %s: %s", resp.Status, body)
+	}
+
+	var parsed openAIChatResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Message{}, fmt.Errorf("unmarshal openai response: %w", err)
+	}
+	if len(parsed.Choices) != 1 {
+		return Message{}, fmt.Errorf("openai returned %d choices, want 1", len(parsed.Choices))
+	}
+	msg := parsed.Choices[0].Message.toMessage()
+	msg.FinishReason = parsed.Choices[0].FinishReason
+	msg.Timestamp = time.Now()
+	return msg, nil
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, convo Conversation, params GenParams) (<-chan Delta, error) {
+	req, err := p.newRequest(ctx, convo, params, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("openai returned %s: %s", resp.Status, body)
+	}
+
+	deltas := make(chan Delta)
+	go func() {
+		defer resp.Body.Close()
+		defer close(deltas)
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if payload == "[DONE]" {
+				deltas <- Delta{Done: true}
+				return
+			}
+			var chunk openAIChatStreamChunk
+			if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+				continue
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+			select {
+			case deltas <- Delta{
+				Content:      chunk.Choices[0].Delta.Content,
+				FinishReason: chunk.Choices[0].FinishReason,
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		deltas <- Delta{Done: true}
+	}()
+	return deltas, nil
+}
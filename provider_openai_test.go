@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestToOpenAIMessagesOmitsArchiveFields(t *testing.T) {
+	messages := toOpenAIMessages([]Message{
+		{Role: "user", Content: "hi", Timestamp: time.Now(), FinishReason: "stop"},
+	})
+
+	data, err := json.Marshal(messages[0])
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for _, field := range []string{"timestamp", "finish_reason", "tool_call_id", "name", "tool_calls"} {
+		if _, ok := raw[field]; ok {
+			t.Errorf("wire message unexpectedly carries %q: %s", field, data)
+		}
+	}
+}
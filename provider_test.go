@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewProviderDispatchesByName(t *testing.T) {
+	cases := map[string]string{
+		"openai":    "openai",
+		"anthropic": "anthropic",
+		"ollama":    "ollama",
+		"llamacpp":  "llamacpp",
+	}
+	for name, wantName := range cases {
+		p, err := NewProvider(name, ProviderConfig{})
+		if err != nil {
+			t.Errorf("NewProvider(%q): %v", name, err)
+			continue
+		}
+		if p.Name() != wantName {
+			t.Errorf("NewProvider(%q).Name() = %q, want %q", name, p.Name(), wantName)
+		}
+	}
+}
+
+func TestNewProviderUnknownName(t *testing.T) {
+	_, err := NewProvider("nonexistent", ProviderConfig{})
+	if !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("got %v, want an error wrapping ErrProviderUnavailable", err)
+	}
+}
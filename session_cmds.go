@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// cmdList implements `shllm ls`: every conversation in the archive,
+// grouped by the date it was created.
+func cmdList(args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ExitOnError)
+	fs.Parse(args)
+
+	dir, err := archiveDir()
+	if err != nil {
+		return err
+	}
+	store := newJSONFileStore(dir)
+	metas, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	lastDate := ""
+	for _, m := range metas {
+		if m.Created != lastDate {
+			fmt.Printf("%s\n", m.Created)
+			lastDate = m.Created
+		}
+		fmt.Printf("  %s  %-30s (%d messages)\n", m.ID, m.Title, m.Messages)
+	}
+	return nil
+}
+
+// cmdResume implements `shllm resume <id|title>`, rehydrating a prior
+// conversation and continuing it in the normal REPL.
+func cmdResume(args []string) error {
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	provider, model, baseURL, configPath := providerFlags(fs)
+	var tools, yolo bool
+	var toolDir string
+	var toolTimeout time.Duration
+	toolFlags(fs, &tools, &yolo, &toolDir, &toolTimeout)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: shllm resume <id|title>")
+	}
+
+	pArgs := parsedArgs{
+		provider: *provider, model: *model, baseURL: *baseURL, configPath: *configPath,
+		tools: tools, yolo: yolo, toolDir: toolDir, toolTimeout: toolTimeout,
+	}
+	p, err := resolveProvider(pArgs)
+	if err != nil {
+		return err
+	}
+	toolCfg, err := resolveToolConfig(pArgs)
+	if err != nil {
+		return err
+	}
+
+	dir, err := archiveDir()
+	if err != nil {
+		return err
+	}
+	store := newJSONFileStore(dir)
+	convo, err := store.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return replLoop(p, convo, store.FilePath(convo), toolCfg)
+}
+
+// cmdFork implements `shllm fork <id|title> [--at N]`, branching a new
+// conversation off of an existing one at message N (or the end, if --at is
+// omitted).
+func cmdFork(args []string) error {
+	fs := flag.NewFlagSet("fork", flag.ExitOnError)
+	at := fs.Int("at", -1, "branch after message N instead of at the end")
+	provider, model, baseURL, configPath := providerFlags(fs)
+	var tools, yolo bool
+	var toolDir string
+	var toolTimeout time.Duration
+	toolFlags(fs, &tools, &yolo, &toolDir, &toolTimeout)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: shllm fork <id|title> [--at N]")
+	}
+
+	pArgs := parsedArgs{
+		provider: *provider, model: *model, baseURL: *baseURL, configPath: *configPath,
+		tools: tools, yolo: yolo, toolDir: toolDir, toolTimeout: toolTimeout,
+	}
+	p, err := resolveProvider(pArgs)
+	if err != nil {
+		return err
+	}
+	toolCfg, err := resolveToolConfig(pArgs)
+	if err != nil {
+		return err
+	}
+
+	dir, err := archiveDir()
+	if err != nil {
+		return err
+	}
+	store := newJSONFileStore(dir)
+	convo, err := store.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *at >= 0 {
+		messages, err := forkAtMessages(convo.Messages, *at)
+		if err != nil {
+			return err
+		}
+		convo.Messages = messages
+	}
+
+	id, err := newConversationID()
+	if err != nil {
+		return err
+	}
+	convo.ID = id
+	convo.CreatedAt = time.Now()
+	convo.Title = convo.Title + "_fork"
+
+	return replLoop(p, convo, store.FilePath(convo), toolCfg)
+}
+
+// forkAtMessages returns the messages preceding fork point at (exclusive),
+// copied so the caller can't mutate the source conversation through the
+// result. at must be within [0, len(messages)]; one past the end is the
+// valid "keep everything" case, covering a straight copy-and-rename fork.
+func forkAtMessages(messages []Message, at int) ([]Message, error) {
+	if at > len(messages) {
+		return nil, fmt.Errorf("fork point %d is past the end of the conversation (%d messages)", at, len(messages))
+	}
+	return append([]Message(nil), messages[:at]...), nil
+}
+
+// cmdSearch implements `shllm search <query>`, a regex or substring scan
+// of message content across the whole archive, optionally bounded by
+// -since/-until (time.DateOnly).
+func cmdSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	useRegex := fs.Bool("regex", false, "treat query as a regular expression instead of a substring")
+	since := fs.String("since", "", "only match messages on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "only match messages on or before this date (YYYY-MM-DD)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: shllm search [OPTIONS] <query>")
+	}
+	query := fs.Arg(0)
+
+	var sinceT, untilT time.Time
+	var err error
+	if *since != "" {
+		if sinceT, err = time.Parse(time.DateOnly, *since); err != nil {
+			return fmt.Errorf("parse -since: %w", err)
+		}
+	}
+	if *until != "" {
+		if untilT, err = time.Parse(time.DateOnly, *until); err != nil {
+			return fmt.Errorf("parse -until: %w", err)
+		}
+		untilT = untilT.Add(24 * time.Hour)
+	}
+
+	var re *regexp.Regexp
+	if *useRegex {
+		re, err = regexp.Compile(query)
+		if err != nil {
+			return fmt.Errorf("compile -regex query: %w", err)
+		}
+	}
+	matches := func(content string) bool {
+		if re != nil {
+			return re.MatchString(content)
+		}
+		return strings.Contains(strings.ToLower(content), strings.ToLower(query))
+	}
+
+	dir, err := archiveDir()
+	if err != nil {
+		return err
+	}
+	store := newJSONFileStore(dir)
+	convos, err := store.All()
+	if err != nil {
+		return err
+	}
+
+	for _, convo := range convos {
+		for _, m := range convo.Messages {
+			if !sinceT.IsZero() && m.Timestamp.Before(sinceT) {
+				continue
+			}
+			if !untilT.IsZero() && m.Timestamp.After(untilT) {
+				continue
+			}
+			if matches(m.Content) {
+				fmt.Printf("%s  %s  %s: %s\n", convo.ID, m.Timestamp.Format(time.RFC3339), m.Role, truncate(m.Content, 100))
+			}
+		}
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// cmdExport implements `shllm export <id|title> --format md|txt|json`.
+func cmdExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "txt", "output format: md, txt or json")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: shllm export [OPTIONS] <id|title>")
+	}
+
+	dir, err := archiveDir()
+	if err != nil {
+		return err
+	}
+	store := newJSONFileStore(dir)
+	convo, err := store.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(convo, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal conversation: %w", err)
+		}
+		fmt.Println(string(data))
+	case "md":
+		fmt.Printf("# %s\n\n", convo.Title)
+		for _, m := range convo.Messages {
+			fmt.Printf("**%s** _(%s)_:\n\n%s\n\n", m.Role, m.Timestamp.Format(time.RFC3339), m.Content)
+		}
+	case "txt":
+		for _, m := range convo.Messages {
+			fmt.Printf("[%s] %s: %s\n", m.Timestamp.Format(time.RFC3339), m.Role, m.Content)
+		}
+	default:
+		return fmt.Errorf("unknown -format %q, want md, txt or json", *format)
+	}
+	return nil
+}
+
+// providerFlags registers the -provider/-model/-base-url/-config flags
+// shared by every subcommand that talks to an LLM.
+func providerFlags(fs *flag.FlagSet) (provider, model, baseURL, configPath *string) {
+	provider = fs.String("provider", "", "LLM provider to use: openai, anthropic, ollama, llamacpp (default from config, else openai)")
+	model = fs.String("model", "", "model name to request (default from config, else "+defaultModel+")")
+	baseURL = fs.String("base-url", "", "override the provider's API base URL")
+	configPath = fs.String("config", "", "path to config.toml (default "+defaultConfigPath+")")
+	return provider, model, baseURL, configPath
+}
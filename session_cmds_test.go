@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestForkAtMessages(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "one"},
+		{Role: "assistant", Content: "two"},
+		{Role: "user", Content: "three"},
+	}
+
+	got, err := forkAtMessages(messages, 2)
+	if err != nil {
+		t.Fatalf("forkAtMessages: %v", err)
+	}
+	if len(got) != 2 || got[1].Content != "two" {
+		t.Errorf("got %+v, want the first 2 messages", got)
+	}
+
+	// The result must not alias the source slice's backing array.
+	got[0].Content = "mutated"
+	if messages[0].Content != "one" {
+		t.Error("forkAtMessages result aliases the input slice")
+	}
+}
+
+func TestForkAtMessagesAtEnd(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "one"}}
+
+	got, err := forkAtMessages(messages, len(messages))
+	if err != nil {
+		t.Fatalf("forkAtMessages: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d messages, want 1", len(got))
+	}
+}
+
+func TestForkAtMessagesPastEnd(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "one"}}
+
+	if _, err := forkAtMessages(messages, 2); err == nil {
+		t.Error("got nil error for an out-of-range fork point, want one")
+	}
+}
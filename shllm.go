@@ -4,66 +4,38 @@ shllm is a CLI REPL for talking with an LLM
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"os/user"
 	"path/filepath"
 	"regexp"
-	"runtime"
-	"strconv"
 	"strings"
 	"time"
-
-	"github.com/chzyer/readline"
 )
 
-// AssertionFailedError represents a custom error type for failed assertions.
-type AssertionFailedError struct {
-	File     string
-	Line     int
-	Expr     string
-	Expected interface{}
-	Actual   interface{}
-}
-
-// Error returns the formatted error message.
-func (e *AssertionFailedError) Error() string {
-	return fmt.Sprintf(`%s:%d: assertion failed: %s
-Expected: %v
-Actual: %v
-`, e.File, e.Line, e.Expr, e.Expected, e.Actual)
-}
-
-// assert checks if the condition is true, and if not, it raises an AssertionFailedError.
-func assert(condition bool, format string, a ...interface{}) {
-	if !condition {
-		_, file, line, _ := runtime.Caller(1)
-		expression := fmt.Sprintf(format, a...)
-		panic(&AssertionFailedError{File: file, Line: line, Expr: expression})
-	}
-}
-
-func ensureDir(dirPath string) {
+func ensureDir(dirPath string) error {
 	_, err := os.Stat(dirPath)
 	if os.IsNotExist(err) {
-		err := os.MkdirAll(dirPath, os.ModePerm)
-		if err != nil {
-			panic("failed to create dir")
+		if err := os.MkdirAll(dirPath, os.ModePerm); err != nil {
+			return fmt.Errorf("create dir %s: %w", dirPath, err)
 		}
+		return nil
 	} else if err != nil {
-		panic("failed to check dir existence")
+		return fmt.Errorf("check dir %s: %w", dirPath, err)
 	}
+	return nil
 }
 
 func expandUser(path string) string {
 	currentUser, err := user.Current()
 	if err != nil {
-		panic("failed to get current user")
+		// Can't resolve "~" without a user record; return path unexpanded
+		// rather than aborting the whole program over it.
+		return path
 	}
 	if path == "~" {
 		// In case of "~", which won't be caught by the "else if"
@@ -82,76 +54,155 @@ func notesDir() string {
 	return expandUser(notesDir)
 }
 
-func getSessionFilePath(filePath string) string {
-	var fileName string
+// archiveDir returns $notes/shllm, creating it if necessary. It's the root
+// every daily archive file, and the ConversationStore built on top of them,
+// lives under.
+func archiveDir() (string, error) {
+	dir := filepath.Join(notesDir(), "shllm")
+	if err := ensureDir(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
 
+func getSessionFilePath(filePath string) (string, error) {
 	if filePath != "" {
-		return filePath
+		return filePath, nil
 	}
-	notesDir := notesDir()
-	currentDate := time.Now().Format(time.DateOnly)
-
-	fileName = fmt.Sprintf("%s.json", currentDate)
-	archiveDir := filepath.Join(notesDir, "shllm")
-	ensureDir(archiveDir)
-	return filepath.Join(archiveDir, fileName)
+	dir, err := archiveDir()
+	if err != nil {
+		return "", err
+	}
+	fileName := fmt.Sprintf("%s.json", time.Now().Format(time.DateOnly))
+	return filepath.Join(dir, fileName), nil
 }
 
-func saveConversation(filePath string, convo *Conversation) {
+// failWhale is the last-resort path when we can't write the conversation to
+// its intended session file: dump it to a tempfile in the current directory
+// so the user's conversation isn't lost outright.
+func failWhale(writeData []byte, convo *Conversation) (string, error) {
+	tempFile, err := ioutil.TempFile(".", "tempfile-")
+	if err != nil {
+		return "", fmt.Errorf("open fail-whale tempfile: %w", err)
+	}
+	defer tempFile.Close()
 
-	failWhale := func(writeData []byte, convo *Conversation) string {
-		tempFile, err := ioutil.TempFile(".", "tempfile-")
+	if len(writeData) == 0 {
+		writeData, err = json.Marshal(*convo)
 		if err != nil {
-			panic("the fail whale failed opening tempfile")
+			return "", fmt.Errorf("marshal conversation for fail-whale tempfile: %w", err)
 		}
-		nbytes, err := tempFile.Write(writeData)
-		if err != nil || nbytes <= 0 {
-			fallbackMarshalledConvo, err := json.Marshal(*convo)
-			if err != nil {
-				panic("the fail whale failed marshalling data")
-			}
-			nbytes, err := tempFile.Write(fallbackMarshalledConvo)
-			if err != nil || nbytes <= 0 {
-				panic("the fail whale failed writing to tempfile")
-			}
+	}
+	if _, err := tempFile.Write(writeData); err != nil {
+		return "", fmt.Errorf("write fail-whale tempfile: %w", err)
+	}
+	return tempFile.Name(), nil
+}
+
+// rotateCorruptSessionFile moves an unparseable session file out of the way
+// so a fresh one can be written in its place, instead of silently
+// overwriting whatever the user had saved there before.
+func rotateCorruptSessionFile(filePath string) (string, error) {
+	corruptPath := fmt.Sprintf("%s.corrupt-%d.json", filePath, time.Now().Unix())
+	if err := os.Rename(filePath, corruptPath); err != nil {
+		return "", fmt.Errorf("rotate corrupt session file: %w", err)
+	}
+	return corruptPath, nil
+}
+
+// upsertConversation replaces the conversation in list sharing convo's ID
+// (falling back to Title for conversations predating stable IDs), or
+// appends convo if none matches. This lets saveConversation be called
+// repeatedly for the same in-progress conversation (e.g. from the autosave
+// loop) without piling up duplicate entries.
+func upsertConversation(list *ConversationList, convo Conversation) {
+	for i := range list.Conversations {
+		match := convo.ID != "" && list.Conversations[i].ID == convo.ID
+		match = match || (convo.ID == "" && list.Conversations[i].Title == convo.Title)
+		if match {
+			list.Conversations[i] = convo
+			return
 		}
-		return tempFile.Name()
 	}
+	list.Conversations = append(list.Conversations, convo)
+}
+
+func saveConversation(filePath string, convo *Conversation) error {
 	var convoList ConversationList
 
 	data, err := os.ReadFile(filePath)
-	if os.IsNotExist(err) || len(data) == 0 {
-		data = []byte("{\"version\": 1.0}")
-	} else if err != nil {
-		failWhaleFileName := failWhale(nil, convo)
-		panic(fmt.Sprintf("error reading data. Your conversation was saved in the current directory: %s", failWhaleFileName))
+	switch {
+	case os.IsNotExist(err), len(data) == 0:
+		data = []byte(`{"version": 1.0}`)
+	case err != nil:
+		return fmt.Errorf("read session file %s: %w", filePath, err)
 	}
-	err = json.Unmarshal(data, &convoList)
-	if err != nil {
-		failWhaleFileName := failWhale(nil, convo)
-		panic(fmt.Sprintf("error unmarshalling data. Your conversation was saved in the current directory: %s", failWhaleFileName))
+
+	if err := json.Unmarshal(data, &convoList); err != nil {
+		corruptPath, rotateErr := rotateCorruptSessionFile(filePath)
+		if rotateErr != nil {
+			if _, failErr := failWhale(nil, convo); failErr != nil {
+				return fmt.Errorf("%w: %s (also failed fail-whale: %s)", ErrSessionCorrupt, rotateErr, failErr)
+			}
+			return fmt.Errorf("%w: %s", ErrSessionCorrupt, rotateErr)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s was unparseable, moved to %s; starting a fresh session file\n", filePath, corruptPath)
+		convoList = ConversationList{Version: currentSchemaVersion}
 	}
+	migrateConversationList(&convoList)
 
-	convoList.Conversations = append(convoList.Conversations, *convo)
+	upsertConversation(&convoList, *convo)
 	writeData, err := json.Marshal(convoList)
-	err = os.WriteFile(filePath, writeData, 0644)
 	if err != nil {
-		failWhaleFileName := failWhale(writeData, convo)
-		panic(fmt.Sprintf("error writing file. Your conversation was saved in the current directory: %s", failWhaleFileName))
+		failWhaleFileName, failErr := failWhale(nil, convo)
+		if failErr != nil {
+			return fmt.Errorf("marshal session: %w (also failed fail-whale: %s)", err, failErr)
+		}
+		return fmt.Errorf("marshal session: %w (conversation saved to %s)", err, failWhaleFileName)
+	}
+	if err := os.WriteFile(filePath, writeData, 0644); err != nil {
+		failWhaleFileName, failErr := failWhale(writeData, convo)
+		if failErr != nil {
+			return fmt.Errorf("write session file %s: %w (also failed fail-whale: %s)", filePath, err, failErr)
+		}
+		return fmt.Errorf("write session file %s: %w (conversation saved to %s)", filePath, err, failWhaleFileName)
 	}
+	return nil
 }
 
 // Message exported for use with the API definition
 type Message struct {
-	Role      string    `json:"role"`
-	Content   string    `json:"content"`
-	Timestamp time.Time `json:"timestamp"`
+	Role         string     `json:"role"`
+	Content      string     `json:"content"`
+	Timestamp    time.Time  `json:"timestamp"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID and Name are only set on role == "tool" messages, linking
+	// a tool's result back to the ToolCall that requested it.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// ToolCall is a single function-call request emitted by the model, in the
+// same shape OpenAI-style APIs use.
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction names the tool to invoke and its JSON-encoded arguments.
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // Conversation exported for use with the API definition
 type Conversation struct {
-	Messages []Message `json:"messages"`
-	Title    string    `json:"title"`
+	ID        string    `json:"id,omitempty"`
+	Messages  []Message `json:"messages"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
 }
 
 // ConversationList list of conversations in a file
@@ -160,105 +211,304 @@ type ConversationList struct {
 	Conversations []Conversation `json:"conversations"`
 }
 
-type llmResponse struct {
-	Choices []struct {
-		Message Message `json:"message"`
-	} `json:"choices"`
-}
+// currentSchemaVersion is written to every archive file saveConversation
+// touches. Bumped to 2 when Message grew ToolCalls/ToolCallID/Name, so
+// replaying an older file can tell it hasn't seen those fields.
+const currentSchemaVersion = float32(2.0)
 
-func llmUpdateConvo(convo Conversation) Conversation {
-	defaultHeaders := map[string]string{
-		"Content-Type": "application/json",
-	}
-	jsonBody, err := json.Marshal(convo)
-	if err != nil {
-		panic("couldn't marshal json")
+// migrateConversationList brings list up to currentSchemaVersion in place.
+// Versions before 2 never had tool-call messages, so there's nothing to
+// transform yet; this exists so the next schema change has a single place
+// to add a migration step instead of scattering version checks.
+func migrateConversationList(list *ConversationList) {
+	if list.Version >= currentSchemaVersion {
+		return
 	}
-	req, err := http.NewRequest(
-		"POST",
-		"https://free.churchless.tech/v1/chat/completions",
-		bytes.NewBuffer(jsonBody),
-	)
-	if err != nil {
-		panic("failed to create request")
-	}
-	for name, headerVal := range defaultHeaders {
-		req.Header.Set(name, headerVal)
-	}
-	ret, err := (&http.Client{}).Do(req)
+	list.Version = currentSchemaVersion
+}
+
+// streamUpdateConvo streams the assistant's reply, printing tokens as they
+// arrive. A spinner covers the gap before the first token, and a Ctrl-C read
+// from keys cancels generation mid-flight without tearing down the REPL.
+func streamUpdateConvo(provider Provider, convo Conversation, params GenParams, keys <-chan byte) (Conversation, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deltas, err := provider.Stream(ctx, convo, params)
 	if err != nil {
-		panic("network failure")
+		return convo, fmt.Errorf("%s: %w", provider.Name(), err)
 	}
-	body, err := ioutil.ReadAll(ret.Body)
-	if err != nil {
-		panic("failure to read body")
+
+	spin := newSpinner("\001\033[36m\002assistant is thinking\001\033[39m\002")
+	spinStopped := false
+	stopSpinner := func() {
+		if !spinStopped {
+			spin.Stop()
+			spinStopped = true
+		}
 	}
-	defer ret.Body.Close()
+	defer stopSpinner()
 
-	var jsonData llmResponse
+	var content strings.Builder
+	finishReason := ""
+	printedHeader := false
 
-	err = json.Unmarshal(body, &jsonData)
-	if err != nil {
-		panic("malformed response!")
+loop:
+	for {
+		select {
+		case b, ok := <-keys:
+			if ok && b == keyCtrlC {
+				cancel()
+			}
+		case delta, ok := <-deltas:
+			if !ok {
+				break loop
+			}
+			if delta.Content != "" {
+				stopSpinner()
+				if !printedHeader {
+					fmt.Print("\001\033[36m\002assistant => \001\033[39m\002")
+					printedHeader = true
+				}
+				fmt.Print(delta.Content)
+				content.WriteString(delta.Content)
+			}
+			if delta.FinishReason != "" {
+				finishReason = delta.FinishReason
+			}
+			if delta.Done {
+				break loop
+			}
+		}
+	}
+	stopSpinner()
+	if ctx.Err() != nil && finishReason == "" {
+		finishReason = "cancelled"
 	}
-	choices := jsonData.Choices
-	assert(
-		len(choices) >= 0 && len(choices) <= 1,
-		"len(choices) == "+strconv.Itoa(len(choices)),
-	)
-	choices[0].Message.Timestamp = time.Now()
-	convo.Messages = append(convo.Messages, choices[0].Message)
+	fmt.Println()
 
-	return convo
+	convo.Messages = append(convo.Messages, Message{
+		Role:         "assistant",
+		Content:      content.String(),
+		Timestamp:    time.Now(),
+		FinishReason: finishReason,
+	})
+	return convo, nil
 }
 
+const (
+	defaultProvider = "openai"
+	defaultModel    = "gpt-3.5-turbo"
+)
+
 type parsedArgs struct {
-	filePath string
+	filePath    string
+	provider    string
+	model       string
+	baseURL     string
+	configPath  string
+	tools       bool
+	yolo        bool
+	toolDir     string
+	toolTimeout time.Duration
 }
 
-func parseArgs() (parsedArgs, string) {
+func parseArgs(args []string) (parsedArgs, string) {
 	flags := parsedArgs{}
 	reg := regexp.MustCompile("[^a-zA-Z0-9_]+")
 
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] [SESSION NAME]\n", filepath.Base(os.Args[0]))
-		fmt.Fprintf(os.Stderr, "Talk to chatgpt from the command line\n")
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s new [OPTIONS] [SESSION NAME]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Talk to an LLM from the command line\n")
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
-		flag.PrintDefaults()
+		fs.PrintDefaults()
 	}
-	flag.StringVar(&flags.filePath, "filepath", "", "filepath to save session to")
-	flag.StringVar(&flags.filePath, "f", "", "filepath to use (shorthand)")
-	flag.Parse()
+	fs.StringVar(&flags.filePath, "filepath", "", "filepath to save session to")
+	fs.StringVar(&flags.filePath, "f", "", "filepath to use (shorthand)")
+	fs.StringVar(&flags.provider, "provider", "", "LLM provider to use: openai, anthropic, ollama, llamacpp (default from config, else openai)")
+	fs.StringVar(&flags.model, "model", "", "model name to request (default from config, else "+defaultModel+")")
+	fs.StringVar(&flags.baseURL, "base-url", "", "override the provider's API base URL")
+	fs.StringVar(&flags.configPath, "config", "", "path to config.toml (default "+defaultConfigPath+")")
+	toolFlags(fs, &flags.tools, &flags.yolo, &flags.toolDir, &flags.toolTimeout)
+	fs.Parse(args)
 
-	args := flag.Args()
-	sessionTitle := strings.ToLower(reg.ReplaceAllString(strings.Join(args, "_"), ""))
+	sessionTitle := strings.ToLower(reg.ReplaceAllString(strings.Join(fs.Args(), "_"), ""))
 	if sessionTitle == "" {
 		sessionTitle = fmt.Sprintf("unnamed_session_%s", time.Now().Format(time.DateTime))
 	}
 	return flags, sessionTitle
 }
 
-func main() {
-	flags, sessionTitle := parseArgs()
-	rl, err := readline.New("\001\033[36m\002human\t  => \001\033[39m\002")
+// resolveProvider merges config file defaults with -provider/-model/-base-url
+// flags (flags win) and constructs the resulting Provider.
+func resolveProvider(flags parsedArgs) (Provider, error) {
+	configPath := flags.configPath
+	if configPath == "" {
+		configPath = defaultConfigFilePath()
+	}
+	cfg, err := loadConfig(configPath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	defer rl.Close()
-	convo := Conversation{Title: sessionTitle}
 
-	filePath := getSessionFilePath(flags.filePath)
-	defer saveConversation(filePath, &convo)
+	providerName := flags.provider
+	if providerName == "" {
+		providerName = cfg.Provider
+	}
+	if providerName == "" {
+		providerName = defaultProvider
+	}
 
-	for {
-		line, err := rl.Readline()
-		if err != nil {
-			break
+	model := flags.model
+	if model == "" {
+		model = cfg.Model
+	}
+	if model == "" {
+		model = defaultModel
+	}
+
+	baseURL := flags.baseURL
+	if baseURL == "" {
+		baseURL = cfg.BaseURL
+	}
+
+	return NewProvider(providerName, ProviderConfig{Model: model, BaseURL: baseURL})
+}
+
+// replState is the mutable session state slash commands operate on: the
+// running conversation, the provider and sampling params in effect, and
+// where autosaves go.
+type replState struct {
+	convo      Conversation
+	provider   Provider
+	params     GenParams
+	filePath   string
+	autosave   *autosaver
+	toolConfig toolConfig
+}
+
+// replLoop drives the interactive REPL against provider for convo,
+// autosaving to filePath after each user message and each reply. It's shared by
+// every subcommand that ends up talking to the model: new, resume and
+// fork all differ only in how convo and filePath are seeded.
+func replLoop(provider Provider, convo Conversation, filePath string, toolCfg toolConfig) error {
+	autosave := newAutosaver(filePath)
+
+	state := &replState{
+		convo:      convo,
+		provider:   provider,
+		filePath:   filePath,
+		autosave:   autosave,
+		toolConfig: toolCfg,
+	}
+
+	err := withRawMode(func() {
+		keys := readKeys(os.Stdin)
+		for {
+			select {
+			case saveErr := <-autosave.Errs():
+				fmt.Fprintf(os.Stderr, "\r\n%s\r\n", saveErr)
+			default:
+			}
+
+			line, lineErr := readLine(keys, "\001\033[36m\002human\t  => \001\033[39m\002")
+			if lineErr != nil {
+				return
+			}
+
+			if strings.HasPrefix(line, "/") {
+				if err := dispatchCommand(state, line, keys); err != nil {
+					fmt.Fprintf(os.Stderr, "\r\n%s\r\n", err)
+				}
+				continue
+			}
+
+			state.convo.Messages = append(state.convo.Messages, Message{Role: "user", Content: line, Timestamp: time.Now()})
+			autosave.Trigger(state.convo)
+
+			var updated Conversation
+			var err error
+			if state.toolConfig.enabled {
+				updated, err = completeWithTools(state.provider, state.convo, state.params, state.toolConfig, keys)
+			} else {
+				updated, err = streamUpdateConvo(state.provider, state.convo, state.params, keys)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "\r\n%s\r\n", err)
+				continue
+			}
+			state.convo = updated
+			autosave.Trigger(state.convo)
 		}
-		convo.Messages = append(convo.Messages, Message{Role: "user", Content: line, Timestamp: time.Now()})
-		convo = llmUpdateConvo(convo)
-		llmResponse := convo.Messages[len(convo.Messages)-1]
+	})
+
+	// Wait for the autosaver to finish any in-flight write before doing the
+	// final synchronous save: both write filePath, and the two racing would
+	// risk one clobbering the other mid read-modify-write.
+	autosave.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return saveConversation(filePath, &state.convo)
+}
+
+// cmdNew starts a brand-new conversation: `shllm new [OPTIONS] [TITLE...]`.
+// A bare `shllm [OPTIONS] [TITLE...]`, with no recognized subcommand, is
+// treated the same way for backward compatibility.
+func cmdNew(args []string) error {
+	flags, sessionTitle := parseArgs(args)
+	provider, err := resolveProvider(flags)
+	if err != nil {
+		return err
+	}
+	toolCfg, err := resolveToolConfig(flags)
+	if err != nil {
+		return err
+	}
+	filePath, err := getSessionFilePath(flags.filePath)
+	if err != nil {
+		return err
+	}
+
+	id, err := newConversationID()
+	if err != nil {
+		return err
+	}
+	convo := Conversation{ID: id, Title: sessionTitle, CreatedAt: time.Now()}
+	return replLoop(provider, convo, filePath, toolCfg)
+}
 
-		fmt.Printf("\001\033[36m\002%s => \001\033[39m\002%s\n", llmResponse.Role, llmResponse.Content)
+func main() {
+	if err := dispatch(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// dispatch routes to shllm's git-style subcommands. Anything that isn't a
+// recognized subcommand name (most commonly a flag, or nothing at all) is
+// treated as arguments to "new".
+func dispatch(args []string) error {
+	if len(args) == 0 {
+		return cmdNew(args)
+	}
+	switch args[0] {
+	case "new":
+		return cmdNew(args[1:])
+	case "ls":
+		return cmdList(args[1:])
+	case "resume":
+		return cmdResume(args[1:])
+	case "fork":
+		return cmdFork(args[1:])
+	case "search":
+		return cmdSearch(args[1:])
+	case "export":
+		return cmdExport(args[1:])
+	default:
+		return cmdNew(args)
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestUpsertConversationInsertsNew(t *testing.T) {
+	list := &ConversationList{}
+	upsertConversation(list, Conversation{ID: "a", Title: "first"})
+
+	if len(list.Conversations) != 1 {
+		t.Fatalf("got %d conversations, want 1", len(list.Conversations))
+	}
+	if list.Conversations[0].ID != "a" {
+		t.Errorf("got ID %q, want %q", list.Conversations[0].ID, "a")
+	}
+}
+
+func TestUpsertConversationReplacesByID(t *testing.T) {
+	list := &ConversationList{Conversations: []Conversation{
+		{ID: "a", Title: "old"},
+		{ID: "b", Title: "other"},
+	}}
+	upsertConversation(list, Conversation{ID: "a", Title: "new"})
+
+	if len(list.Conversations) != 2 {
+		t.Fatalf("got %d conversations, want 2", len(list.Conversations))
+	}
+	if list.Conversations[0].Title != "new" {
+		t.Errorf("got title %q, want %q", list.Conversations[0].Title, "new")
+	}
+}
+
+func TestUpsertConversationReplacesByTitleWhenIDEmpty(t *testing.T) {
+	list := &ConversationList{Conversations: []Conversation{
+		{Title: "untitled"},
+	}}
+	upsertConversation(list, Conversation{Title: "untitled", Messages: []Message{{Role: "user", Content: "hi"}}})
+
+	if len(list.Conversations) != 1 {
+		t.Fatalf("got %d conversations, want 1", len(list.Conversations))
+	}
+	if len(list.Conversations[0].Messages) != 1 {
+		t.Errorf("upsert did not replace the matching untitled conversation")
+	}
+}
+
+func TestMigrateConversationListBumpsOldVersion(t *testing.T) {
+	list := &ConversationList{Version: 1.0}
+	migrateConversationList(list)
+
+	if list.Version != currentSchemaVersion {
+		t.Errorf("got version %v, want %v", list.Version, currentSchemaVersion)
+	}
+}
+
+func TestMigrateConversationListLeavesCurrentVersionAlone(t *testing.T) {
+	list := &ConversationList{Version: currentSchemaVersion}
+	migrateConversationList(list)
+
+	if list.Version != currentSchemaVersion {
+		t.Errorf("got version %v, want %v", list.Version, currentSchemaVersion)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// newConversationID returns a short, effectively-unique identifier for a
+// new conversation. IDs are stable once assigned: they're what resume,
+// fork, search and export use to address a conversation regardless of
+// which archive file it lives in.
+func newConversationID() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate conversation id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ConversationMeta is the lightweight info ConversationStore.List returns,
+// without pulling every message of every conversation into memory.
+type ConversationMeta struct {
+	ID       string
+	Title    string
+	Created  string // first message's date, time.DateOnly, for display/grouping
+	Messages int
+	FilePath string
+}
+
+// ConversationStore indexes conversations by stable ID across the archive,
+// independent of which physical file backs them. The only implementation
+// today is jsonFileStore; a SQLite-backed one could satisfy the same
+// interface without touching callers.
+type ConversationStore interface {
+	// List returns metadata for every conversation in the store, most
+	// recently created first.
+	List() ([]ConversationMeta, error)
+	// Load returns the full conversation identified by id.
+	Load(id string) (Conversation, error)
+	// FilePath returns the archive file convo.CreatedAt maps to, without
+	// reading or writing anything.
+	FilePath(convo Conversation) string
+	// All returns every full conversation in the store, for callers (like
+	// search) that need message content rather than just metadata.
+	All() ([]Conversation, error)
+}
+
+// jsonFileStore is a ConversationStore backed by the existing one-file-
+// per-day JSON archive under archiveDir.
+type jsonFileStore struct {
+	archiveDir string
+}
+
+func newJSONFileStore(archiveDir string) *jsonFileStore {
+	return &jsonFileStore{archiveDir: archiveDir}
+}
+
+// isCorruptArchive reports whether path is a file rotateCorruptSessionFile
+// produced, so List can skip it instead of trying to index it.
+func isCorruptArchive(path string) bool {
+	return strings.Contains(filepath.Base(path), ".corrupt-")
+}
+
+func (s *jsonFileStore) archiveFiles() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(s.archiveDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("glob archive dir %s: %w", s.archiveDir, err)
+	}
+	files := matches[:0]
+	for _, m := range matches {
+		if !isCorruptArchive(m) {
+			files = append(files, m)
+		}
+	}
+	return files, nil
+}
+
+func readConversationList(path string) (ConversationList, error) {
+	var list ConversationList
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return list, fmt.Errorf("read archive file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return list, fmt.Errorf("%w: %s: %s", ErrSessionCorrupt, path, err)
+	}
+	migrateConversationList(&list)
+	return list, nil
+}
+
+func (s *jsonFileStore) List() ([]ConversationMeta, error) {
+	files, err := s.archiveFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var metas []ConversationMeta
+	for _, file := range files {
+		list, err := readConversationList(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, convo := range list.Conversations {
+			created := ""
+			if len(convo.Messages) > 0 {
+				created = convo.Messages[0].Timestamp.Format("2006-01-02")
+			}
+			metas = append(metas, ConversationMeta{
+				ID:       convo.ID,
+				Title:    convo.Title,
+				Created:  created,
+				Messages: len(convo.Messages),
+				FilePath: file,
+			})
+		}
+	}
+	sort.Slice(metas, func(i, j int) bool { return metas[i].Created > metas[j].Created })
+	return metas, nil
+}
+
+// findConversation locates a conversation either by its ID or, failing
+// that, by an exact title match, returning the backing file alongside it.
+func (s *jsonFileStore) findConversation(idOrTitle string) (Conversation, string, error) {
+	files, err := s.archiveFiles()
+	if err != nil {
+		return Conversation{}, "", err
+	}
+	for _, file := range files {
+		list, err := readConversationList(file)
+		if err != nil {
+			return Conversation{}, "", err
+		}
+		for _, convo := range list.Conversations {
+			if convo.ID == idOrTitle || convo.Title == idOrTitle {
+				return convo, file, nil
+			}
+		}
+	}
+	return Conversation{}, "", fmt.Errorf("no conversation matching %q", idOrTitle)
+}
+
+func (s *jsonFileStore) Load(idOrTitle string) (Conversation, error) {
+	convo, _, err := s.findConversation(idOrTitle)
+	return convo, err
+}
+
+func (s *jsonFileStore) All() ([]Conversation, error) {
+	files, err := s.archiveFiles()
+	if err != nil {
+		return nil, err
+	}
+	var all []Conversation
+	for _, file := range files {
+		list, err := readConversationList(file)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, list.Conversations...)
+	}
+	return all, nil
+}
+
+func (s *jsonFileStore) FilePath(convo Conversation) string {
+	createdAt := convo.CreatedAt
+	switch {
+	case !createdAt.IsZero():
+		// use as-is
+	case len(convo.Messages) > 0:
+		createdAt = convo.Messages[0].Timestamp
+	default:
+		createdAt = time.Now()
+	}
+	fileName := fmt.Sprintf("%s.json", createdAt.Format("2006-01-02"))
+	return filepath.Join(s.archiveDir, fileName)
+}
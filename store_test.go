@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeArchive(t *testing.T, dir, name string, list ConversationList) {
+	t.Helper()
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatalf("marshal archive: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+}
+
+func TestJSONFileStoreList(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "2024-01-01.json", ConversationList{
+		Version: currentSchemaVersion,
+		Conversations: []Conversation{
+			{ID: "a", Title: "first", Messages: []Message{{Role: "user", Content: "hi", Timestamp: time.Now()}}},
+		},
+	})
+	if err := os.WriteFile(filepath.Join(dir, "2024-01-01.json.corrupt-1704067200.json"), []byte("not json"), 0o644); err != nil {
+		t.Fatalf("write corrupt archive: %v", err)
+	}
+
+	store := newJSONFileStore(dir)
+	metas, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(metas) != 1 {
+		t.Fatalf("got %d metas, want 1 (corrupt archive should be skipped)", len(metas))
+	}
+	if metas[0].ID != "a" || metas[0].Messages != 1 {
+		t.Errorf("got %+v, want ID=a Messages=1", metas[0])
+	}
+}
+
+func TestJSONFileStoreFindConversation(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "2024-01-01.json", ConversationList{
+		Version: currentSchemaVersion,
+		Conversations: []Conversation{
+			{ID: "a", Title: "first"},
+			{ID: "b", Title: "second"},
+		},
+	})
+
+	store := newJSONFileStore(dir)
+
+	if convo, _, err := store.findConversation("b"); err != nil || convo.Title != "second" {
+		t.Errorf("find by ID: got %+v, %v", convo, err)
+	}
+	if convo, _, err := store.findConversation("first"); err != nil || convo.ID != "a" {
+		t.Errorf("find by title: got %+v, %v", convo, err)
+	}
+	if _, _, err := store.findConversation("nope"); err == nil {
+		t.Error("find by unknown id/title: got nil error, want one")
+	}
+}
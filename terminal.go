@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	keyCtrlC      = 0x03
+	keyCtrlD      = 0x04
+	keyBackspace  = 0x7f
+	keyBackspace2 = 0x08
+	keyEnter      = '\r'
+	keyNewline    = '\n'
+)
+
+// errInterrupted signals that the user pressed Ctrl-C or Ctrl-D while
+// composing a line, and the REPL should exit.
+var errInterrupted = fmt.Errorf("interrupted")
+
+// readKeys spawns a goroutine that reads raw bytes from r one at a time and
+// publishes them on the returned channel. The channel is closed when r
+// returns an error (including on process exit). A single long-lived reader
+// lets both line-editing and the in-flight-generation Ctrl-C watcher share
+// one stdin without racing each other for bytes.
+func readKeys(r io.Reader) <-chan byte {
+	keys := make(chan byte)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 1)
+		for {
+			n, err := r.Read(buf)
+			if err != nil {
+				return
+			}
+			if n > 0 {
+				keys <- buf[0]
+			}
+		}
+	}()
+	return keys
+}
+
+// readLine echoes and assembles a line of input from keys, a raw byte stream
+// produced by readKeys. It returns errInterrupted on Ctrl-C, or on Ctrl-D
+// with an empty line (matching the previous readline-based behavior).
+func readLine(keys <-chan byte, prompt string) (string, error) {
+	fmt.Print(prompt)
+	var line []byte
+	for b := range keys {
+		switch b {
+		case keyCtrlC:
+			fmt.Print("\r\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(line) == 0 {
+				fmt.Print("\r\n")
+				return "", errInterrupted
+			}
+		case keyEnter, keyNewline:
+			fmt.Print("\r\n")
+			return string(line), nil
+		case keyBackspace, keyBackspace2:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				fmt.Print("\b \b")
+			}
+		default:
+			line = append(line, b)
+			fmt.Printf("%c", b)
+		}
+	}
+	return "", io.EOF
+}
+
+// spinner prints a small rotating indicator on the current line until
+// stop() is called, then clears it.
+type spinner struct {
+	stop func()
+	done chan struct{}
+}
+
+func newSpinner(label string) *spinner {
+	frames := []rune{'|', '/', '-', '\\'}
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		i := 0
+		for {
+			select {
+			case <-quit:
+				fmt.Printf("\r\033[K")
+				return
+			case <-ticker.C:
+				fmt.Printf("\r%s %c\033[K", label, frames[i%len(frames)])
+				i++
+			}
+		}
+	}()
+	return &spinner{
+		stop: func() { close(quit) },
+		done: done,
+	}
+}
+
+func (s *spinner) Stop() {
+	s.stop()
+	<-s.done
+}
+
+// withRawMode puts stdin into raw mode for the duration of fn, restoring it
+// on return (even on panic).
+func withRawMode(fn func()) error {
+	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("enable raw mode: %w", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), oldState)
+	fn()
+	return nil
+}
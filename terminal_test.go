@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func feedKeys(s string) <-chan byte {
+	keys := make(chan byte, len(s))
+	for i := 0; i < len(s); i++ {
+		keys <- s[i]
+	}
+	close(keys)
+	return keys
+}
+
+func TestReadLineReturnsOnEnter(t *testing.T) {
+	line, err := readLine(feedKeys("hello\r"), "")
+	if err != nil {
+		t.Fatalf("readLine: %v", err)
+	}
+	if line != "hello" {
+		t.Errorf("got %q, want %q", line, "hello")
+	}
+}
+
+func TestReadLineHandlesBackspace(t *testing.T) {
+	line, err := readLine(feedKeys("hellz\x7fo\n"), "")
+	if err != nil {
+		t.Fatalf("readLine: %v", err)
+	}
+	if line != "hello" {
+		t.Errorf("got %q, want %q", line, "hello")
+	}
+}
+
+func TestReadLineCtrlCInterrupts(t *testing.T) {
+	_, err := readLine(feedKeys("abc\x03"), "")
+	if err != errInterrupted {
+		t.Errorf("got %v, want errInterrupted", err)
+	}
+}
+
+func TestReadLineCtrlDOnEmptyLineInterrupts(t *testing.T) {
+	_, err := readLine(feedKeys("\x04"), "")
+	if err != errInterrupted {
+		t.Errorf("got %v, want errInterrupted", err)
+	}
+}
+
+func TestReadKeysPublishesBytes(t *testing.T) {
+	keys := readKeys(strings.NewReader("hi"))
+	var got []byte
+	for b := range keys {
+		got = append(got, b)
+	}
+	if string(got) != "hi" {
+		t.Errorf("got %q, want %q", got, "hi")
+	}
+}
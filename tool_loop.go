@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// toolConfig holds the -tools/-yolo/-tool-dir/-tool-timeout settings for a
+// session. The zero value means tool-calling is off.
+type toolConfig struct {
+	enabled bool
+	yolo    bool
+	workDir string
+	timeout time.Duration
+}
+
+// toolFlags registers the -tools/-yolo/-tool-dir/-tool-timeout flags shared
+// by every subcommand that starts a REPL.
+func toolFlags(fs *flag.FlagSet, tools, yolo *bool, toolDir *string, toolTimeout *time.Duration) {
+	fs.BoolVar(tools, "tools", false, "let the model call local tools (read_file, write_file, list_dir, web_fetch, shell)")
+	fs.BoolVar(yolo, "yolo", false, "run tool calls without confirmation (dangerous with the shell tool)")
+	fs.StringVar(toolDir, "tool-dir", "", "directory the file tools are confined to and the shell tool starts in (default: current directory, unconfined)")
+	fs.DurationVar(toolTimeout, "tool-timeout", defaultShellTimeout, "timeout for each tool call, including the shell tool")
+}
+
+// resolveToolConfig builds the toolConfig for flags and, if tools are
+// enabled, registers the shell and web_fetch tools with their session-
+// specific settings (working dir/timeout, and the config file's
+// web_fetch_allowlist).
+func resolveToolConfig(flags parsedArgs) (toolConfig, error) {
+	cfg := toolConfig{
+		enabled: flags.tools,
+		yolo:    flags.yolo,
+		workDir: flags.toolDir,
+		timeout: flags.toolTimeout,
+	}
+	if !cfg.enabled {
+		return cfg, nil
+	}
+
+	configPath := flags.configPath
+	if configPath == "" {
+		configPath = defaultConfigFilePath()
+	}
+	fileCfg, err := loadConfig(configPath)
+	if err != nil {
+		return cfg, err
+	}
+
+	configureShellTool(cfg.workDir, cfg.timeout)
+	configureFileTools(cfg.workDir)
+	configureWebFetchTool(fileCfg.WebFetchAllowlist)
+	return cfg, nil
+}
+
+// completeWithTools drives a non-streaming request/response loop that lets
+// the model call local tools: each assistant reply carrying ToolCalls is
+// answered by invoking them and feeding the results back as "tool" role
+// messages, until the model returns a plain reply with none. It replaces
+// streamUpdateConvo for sessions with tool-calling enabled, since
+// interleaving streamed deltas with partial tool-call argument assembly
+// isn't worth the complexity here.
+func completeWithTools(provider Provider, convo Conversation, params GenParams, toolCfg toolConfig, keys <-chan byte) (Conversation, error) {
+	params.Tools = registeredToolDefs()
+
+	for {
+		spin := newSpinner("\001\033[36m\002assistant is thinking\001\033[39m\002")
+		msg, err := completeCancelable(provider, convo, params, keys)
+		spin.Stop()
+		if err != nil {
+			return convo, fmt.Errorf("%s: %w", provider.Name(), err)
+		}
+		msg.Timestamp = time.Now()
+		convo.Messages = append(convo.Messages, msg)
+
+		if msg.Content != "" {
+			fmt.Printf("\001\033[36m\002assistant => \001\033[39m\002%s\r\n", msg.Content)
+		}
+		if len(msg.ToolCalls) == 0 {
+			return convo, nil
+		}
+
+		for _, call := range msg.ToolCalls {
+			result, err := runTool(call, toolCfg, keys)
+			if err != nil {
+				result = fmt.Sprintf("error: %s", err)
+			}
+			fmt.Printf("\001\033[33m\002tool %s => \001\033[39m\002%s\r\n", call.Function.Name, truncate(result, 200))
+			convo.Messages = append(convo.Messages, Message{
+				Role:       "tool",
+				Content:    result,
+				Timestamp:  time.Now(),
+				ToolCallID: call.ID,
+				Name:       call.Function.Name,
+			})
+		}
+	}
+}
+
+// completeCancelable runs provider.Complete in the background while
+// watching keys the same way streamUpdateConvo watches it for Ctrl-C,
+// cancelling the request's context instead of leaving the byte buffered for
+// replLoop's next readLine call -- which would otherwise be misread as a
+// fresh Ctrl-C there and end the whole session instead of just this turn.
+func completeCancelable(provider Provider, convo Conversation, params GenParams, keys <-chan byte) (Message, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type result struct {
+		msg Message
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		msg, err := provider.Complete(ctx, convo, params)
+		done <- result{msg, err}
+	}()
+
+	for {
+		select {
+		case b, ok := <-keys:
+			if ok && b == keyCtrlC {
+				cancel()
+			}
+		case res := <-done:
+			return res.msg, res.err
+		}
+	}
+}
+
+// runTool looks up and executes a single tool call, confirming with the
+// user first unless toolCfg.yolo is set.
+func runTool(call ToolCall, toolCfg toolConfig, keys <-chan byte) (string, error) {
+	tool, ok := toolRegistry[call.Function.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+
+	if !toolCfg.yolo {
+		confirmed, err := confirmTool(keys, call.Function.Name, call.Function.Arguments)
+		if err != nil {
+			return "", err
+		}
+		if !confirmed {
+			return "", fmt.Errorf("declined by user")
+		}
+	}
+
+	ctx := context.Background()
+	if toolCfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, toolCfg.timeout)
+		defer cancel()
+	}
+	return tool.Invoke(ctx, call.Function.Arguments)
+}
+
+// confirmTool prompts the user to approve a tool call over the same raw
+// key stream the REPL reads its input lines from.
+func confirmTool(keys <-chan byte, name, args string) (bool, error) {
+	prompt := fmt.Sprintf("\001\033[33m\002run tool %s(%s)? [y/N] \001\033[39m\002", name, args)
+	answer, err := readLine(keys, prompt)
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
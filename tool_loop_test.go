@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingProvider's Complete hangs until ctx is cancelled, standing in for
+// a slow/hung endpoint.
+type blockingProvider struct{}
+
+func (p *blockingProvider) Name() string { return "blocking" }
+
+func (p *blockingProvider) Complete(ctx context.Context, _ Conversation, _ GenParams) (Message, error) {
+	<-ctx.Done()
+	return Message{}, ctx.Err()
+}
+
+func (p *blockingProvider) Stream(context.Context, Conversation, GenParams) (<-chan Delta, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestCompleteCancelableCtrlCCancelsTurnOnly(t *testing.T) {
+	keys := make(chan byte)
+	done := make(chan struct{})
+
+	var err error
+	go func() {
+		_, err = completeCancelable(&blockingProvider{}, Conversation{}, GenParams{}, keys)
+		close(done)
+	}()
+
+	keys <- keyCtrlC
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("completeCancelable did not return after Ctrl-C")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
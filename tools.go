@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ToolDef describes a tool's name, purpose and JSON-schema parameters in
+// the shape every provider's function-calling request expects.
+type ToolDef struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// Tool is a locally-executed function the model can call mid-conversation.
+type Tool interface {
+	// Name matches the "name" the model uses in a ToolCall.
+	Name() string
+	// JSONSchema describes the tool for the provider's tools request field.
+	JSONSchema() ToolDef
+	// Invoke runs the tool against args, the raw JSON arguments object the
+	// model supplied, and returns the text to feed back as a tool message.
+	Invoke(ctx context.Context, args string) (string, error)
+}
+
+var toolRegistry = map[string]Tool{}
+
+// RegisterTool adds t to the set of tools offered to the model when
+// tool-calling is enabled. Calling it again for a name already registered
+// replaces that tool, which is how the shell tool picks up its per-session
+// working dir and timeout (see configureShellTool).
+func RegisterTool(t Tool) {
+	toolRegistry[t.Name()] = t
+}
+
+// registeredToolDefs returns every registered Tool's JSONSchema, for
+// building a provider request's tools field.
+func registeredToolDefs() []ToolDef {
+	defs := make([]ToolDef, 0, len(toolRegistry))
+	for _, t := range toolRegistry {
+		defs = append(defs, t.JSONSchema())
+	}
+	return defs
+}
+
+func init() {
+	RegisterTool(newReadFileTool(""))
+	RegisterTool(newWriteFileTool(""))
+	RegisterTool(newListDirTool(""))
+	RegisterTool(newWebFetchTool(nil))
+	RegisterTool(newShellTool("", 0))
+}
+
+// confineToolPath joins workDir and path and rejects the result if a ".."
+// climbs out of workDir. An absolute path is joined under workDir like a
+// relative one. An empty workDir means "no confinement", matching the shell
+// tool's default of running in the process's own working directory.
+func confineToolPath(workDir, path string) (string, error) {
+	if workDir == "" {
+		return path, nil
+	}
+	full := filepath.Join(workDir, path)
+	rel, err := filepath.Rel(workDir, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes the tool working directory %q", path, workDir)
+	}
+	return full, nil
+}
+
+// configureFileTools re-registers read_file/write_file/list_dir confined to
+// workDir, the same -tool-dir every other file-touching tool is bound to,
+// overriding the unconfined instances registered at init().
+func configureFileTools(workDir string) {
+	RegisterTool(newReadFileTool(workDir))
+	RegisterTool(newWriteFileTool(workDir))
+	RegisterTool(newListDirTool(workDir))
+}
+
+// --- read_file ---
+
+// readFileTool reads a file confined to workDir (see confineToolPath); an
+// empty workDir leaves it unconfined, reading relative to the process's own
+// working directory.
+type readFileTool struct{ workDir string }
+
+func newReadFileTool(workDir string) *readFileTool { return &readFileTool{workDir: workDir} }
+
+func (t *readFileTool) Name() string { return "read_file" }
+
+func (t *readFileTool) JSONSchema() ToolDef {
+	return ToolDef{
+		Name:        "read_file",
+		Description: "Read a UTF-8 text file from disk and return its contents.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {"path": {"type": "string", "description": "path to the file"}},
+			"required": ["path"]
+		}`),
+	}
+}
+
+func (t *readFileTool) Invoke(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("read_file: parse arguments: %w", err)
+	}
+	path, err := confineToolPath(t.workDir, params.Path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("read_file %s: %w", params.Path, err)
+	}
+	if info.Size() > maxAttachSize {
+		return "", fmt.Errorf("read_file %s: %d bytes exceeds the %d byte limit", params.Path, info.Size(), maxAttachSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read_file %s: %w", params.Path, err)
+	}
+	return string(data), nil
+}
+
+// --- write_file ---
+
+// writeFileTool writes a file confined to workDir; see readFileTool.
+type writeFileTool struct{ workDir string }
+
+func newWriteFileTool(workDir string) *writeFileTool { return &writeFileTool{workDir: workDir} }
+
+func (t *writeFileTool) Name() string { return "write_file" }
+
+func (t *writeFileTool) JSONSchema() ToolDef {
+	return ToolDef{
+		Name:        "write_file",
+		Description: "Write (overwriting) a UTF-8 text file on disk.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"path": {"type": "string", "description": "path to the file"},
+				"content": {"type": "string", "description": "text to write"}
+			},
+			"required": ["path", "content"]
+		}`),
+	}
+}
+
+func (t *writeFileTool) Invoke(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("write_file: parse arguments: %w", err)
+	}
+	path, err := confineToolPath(t.workDir, params.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(params.Content), 0o644); err != nil {
+		return "", fmt.Errorf("write_file %s: %w", params.Path, err)
+	}
+	return fmt.Sprintf("wrote %d bytes to %s", len(params.Content), params.Path), nil
+}
+
+// --- list_dir ---
+
+// listDirTool lists a directory confined to workDir; see readFileTool.
+type listDirTool struct{ workDir string }
+
+func newListDirTool(workDir string) *listDirTool { return &listDirTool{workDir: workDir} }
+
+func (t *listDirTool) Name() string { return "list_dir" }
+
+func (t *listDirTool) JSONSchema() ToolDef {
+	return ToolDef{
+		Name:        "list_dir",
+		Description: "List the entries of a directory, one per line (directories suffixed with /).",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {"path": {"type": "string", "description": "directory to list, default \".\""}}
+		}`),
+	}
+}
+
+func (t *listDirTool) Invoke(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Path string `json:"path"`
+	}
+	if args != "" {
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", fmt.Errorf("list_dir: parse arguments: %w", err)
+		}
+	}
+	if params.Path == "" {
+		params.Path = "."
+	}
+	path, err := confineToolPath(t.workDir, params.Path)
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", fmt.Errorf("list_dir %s: %w", params.Path, err)
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		fmt.Fprintln(&b, name)
+	}
+	return b.String(), nil
+}
+
+// --- web_fetch ---
+
+// webFetchTool issues a plain HTTP GET, restricted to hosts in allowlist.
+type webFetchTool struct {
+	allowlist map[string]bool
+	client    *http.Client
+}
+
+func newWebFetchTool(allowlist []string) *webFetchTool {
+	hosts := make(map[string]bool, len(allowlist))
+	for _, h := range allowlist {
+		hosts[h] = true
+	}
+	return &webFetchTool{allowlist: hosts, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// configureWebFetchTool re-registers web_fetch with the allowlist read from
+// config.toml for this session, overriding the empty-allowlist instance
+// registered at init() (which otherwise refuses every host).
+func configureWebFetchTool(allowlist []string) {
+	RegisterTool(newWebFetchTool(allowlist))
+}
+
+func (t *webFetchTool) Name() string { return "web_fetch" }
+
+func (t *webFetchTool) JSONSchema() ToolDef {
+	return ToolDef{
+		Name:        "web_fetch",
+		Description: "Fetch a URL over HTTP GET. Only hosts on the configured allowlist are reachable.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {"url": {"type": "string", "description": "URL to fetch"}},
+			"required": ["url"]
+		}`),
+	}
+}
+
+func (t *webFetchTool) Invoke(ctx context.Context, args string) (string, error) {
+	var params struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("web_fetch: parse arguments: %w", err)
+	}
+	parsed, err := url.Parse(params.URL)
+	if err != nil {
+		return "", fmt.Errorf("web_fetch: parse url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("web_fetch: unsupported scheme %q", parsed.Scheme)
+	}
+	if !t.allowlist[parsed.Hostname()] {
+		return "", fmt.Errorf("web_fetch: host %q is not on the allowlist (set web_fetch_allowlist in config.toml)", parsed.Hostname())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", params.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("web_fetch: build request: %w", err)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("web_fetch %s: %w", params.URL, err)
+	}
+	defer resp.Body.Close()
+
+	body := make([]byte, maxAttachSize)
+	n, _ := io.ReadFull(resp.Body, body)
+	return fmt.Sprintf("%s (%s)\n\n%s", params.URL, resp.Status, body[:n]), nil
+}
+
+// --- shell ---
+
+const defaultShellTimeout = 30 * time.Second
+
+// shellTool runs a command through /bin/sh -c, started in workDir and
+// bounded by timeout. Like every tool, it runs only after a confirmation
+// prompt unless -yolo is set (see runTool); it matters most here, since it
+// can do anything the current user can.
+type shellTool struct {
+	workDir string
+	timeout time.Duration
+}
+
+func newShellTool(workDir string, timeout time.Duration) *shellTool {
+	if timeout <= 0 {
+		timeout = defaultShellTimeout
+	}
+	return &shellTool{workDir: workDir, timeout: timeout}
+}
+
+// configureShellTool re-registers the shell tool with the working dir and
+// timeout resolved for this session, overriding the zero-value instance
+// registered at init().
+func configureShellTool(workDir string, timeout time.Duration) {
+	RegisterTool(newShellTool(workDir, timeout))
+}
+
+func (t *shellTool) Name() string { return "shell" }
+
+func (t *shellTool) JSONSchema() ToolDef {
+	return ToolDef{
+		Name:        "shell",
+		Description: "Run a shell command via /bin/sh -c and return its combined stdout/stderr.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {"command": {"type": "string", "description": "command to run"}},
+			"required": ["command"]
+		}`),
+	}
+}
+
+func (t *shellTool) Invoke(ctx context.Context, args string) (string, error) {
+	var params struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("shell: parse arguments: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", params.Command)
+	if t.workDir != "" {
+		cmd.Dir = t.workDir
+	}
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("shell: command timed out after %s", t.timeout)
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		// A non-zero exit is a normal tool result, not a failure to run the
+		// tool: hand the model the output plus the exit status.
+		return fmt.Sprintf("%s\n(exit status %d)", output, exitErr.ExitCode()), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("shell: %w", err)
+	}
+	return string(output), nil
+}